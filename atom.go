@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Atom feed structures
+type AtomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	XMLNS    string      `xml:"xmlns,attr"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Link     AtomLink    `xml:"link"`
+	Icon     string      `xml:"icon,omitempty"`
+	Updated  string      `xml:"updated"`
+	ID       string      `xml:"id"`
+	Author   *AtomAuthor `xml:"author,omitempty"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+type AtomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type AtomEntry struct {
+	Title   string      `xml:"title"`
+	Link    AtomLink    `xml:"link"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Summary AtomContent `xml:"summary"`
+	Content AtomContent `xml:"content"`
+}
+
+type AtomContent struct {
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+func calculateDiscount(current, original float64) int {
+	if original <= 0 || current >= original {
+		return 0
+	}
+	return int(((original - current) / original) * 100)
+}
+
+func parseValidFrom(validFrom string) time.Time {
+	t, err := time.Parse(time.RFC3339, validFrom)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// toAtomFeed converts the neutral Feed model into the Atom XML structures.
+func toAtomFeed(f *Feed) *AtomFeed {
+	entries := make([]AtomEntry, 0, len(f.Items))
+	for _, item := range f.Items {
+		entries = append(entries, AtomEntry{
+			Title: item.Title,
+			Link: AtomLink{
+				Href: item.URL,
+				Rel:  "alternate",
+			},
+			ID:      item.ID,
+			Updated: item.Updated.Format(time.RFC3339),
+			Summary: AtomContent{Type: "text", Content: item.SummaryText},
+			Content: AtomContent{Type: "html", Content: item.ContentHTML},
+		})
+	}
+
+	var author *AtomAuthor
+	if f.AuthorName != "" {
+		author = &AtomAuthor{Name: f.AuthorName, URI: f.AuthorURI}
+	}
+
+	return &AtomFeed{
+		XMLNS:    "http://www.w3.org/2005/Atom",
+		Title:    f.Title,
+		Subtitle: f.Subtitle,
+		Link:     AtomLink{Href: f.Link, Rel: "alternate"},
+		Icon:     f.IconURL,
+		Updated:  f.Updated.Format(time.RFC3339),
+		ID:       f.ID,
+		Author:   author,
+		Entries:  entries,
+	}
+}
+
+// serializeAtom writes f to w as an Atom 1.0 document.
+func serializeAtom(w io.Writer, f *Feed) error {
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(toAtomFeed(f))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache structure
+type Cache struct {
+	mu        sync.RWMutex
+	data      *Feed
+	fetchedAt time.Time
+	duration  time.Duration
+}
+
+func (c *Cache) Get() *Feed {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.data != nil && time.Since(c.fetchedAt) < c.duration {
+		return c.data
+	}
+	return nil
+}
+
+func (c *Cache) Set(feed *Feed) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = feed
+	c.fetchedAt = time.Now()
+}
+
+// Meta reports when the cached feed was fetched and how long it is valid
+// for, so callers can derive HTTP caching headers without reaching into
+// the cached data itself.
+func (c *Cache) Meta() (fetchedAt time.Time, duration time.Duration) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.fetchedAt, c.duration
+}
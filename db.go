@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// HistoryStore persists every advent-calendar entry ever seen for a store,
+// keyed by its stable urn:advent:<productId> id, so subscribers who add
+// the feed mid-December still get all 24 days even after the upstream API
+// stops returning earlier products.
+type HistoryStore struct {
+	db *sql.DB
+}
+
+// OpenHistoryStore opens (creating if necessary) a SQLite database at path
+// using the pure-Go modernc.org/sqlite driver, so the binary stays
+// CGO_ENABLED=0.
+func OpenHistoryStore(path string) (*HistoryStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history db: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to history db: %w", err)
+	}
+	return &HistoryStore{db: db}, nil
+}
+
+// Migrate creates the schema if it doesn't already exist.
+func (h *HistoryStore) Migrate(ctx context.Context) error {
+	_, err := h.db.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS product_history (
+	store        TEXT NOT NULL,
+	id           TEXT NOT NULL,
+	title        TEXT NOT NULL,
+	url          TEXT NOT NULL,
+	content_html TEXT NOT NULL,
+	summary_text TEXT NOT NULL,
+	image_url    TEXT,
+	discount_pct INTEGER NOT NULL,
+	valid_from   TIMESTAMP NOT NULL,
+	first_seen   TIMESTAMP NOT NULL,
+	last_seen    TIMESTAMP NOT NULL,
+	PRIMARY KEY (store, id)
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to migrate history db: %w", err)
+	}
+	return nil
+}
+
+// Upsert records item as having been seen for store just now, refreshing
+// its snapshot but preserving the original first_seen timestamp.
+func (h *HistoryStore) Upsert(ctx context.Context, store string, item FeedItem) error {
+	now := time.Now().UTC()
+	_, err := h.db.ExecContext(ctx, `
+INSERT INTO product_history (store, id, title, url, content_html, summary_text, image_url, discount_pct, valid_from, first_seen, last_seen)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (store, id) DO UPDATE SET
+	title        = excluded.title,
+	url          = excluded.url,
+	content_html = excluded.content_html,
+	summary_text = excluded.summary_text,
+	image_url    = excluded.image_url,
+	discount_pct = excluded.discount_pct,
+	valid_from   = excluded.valid_from,
+	last_seen    = excluded.last_seen
+`, store, item.ID, item.Title, item.URL, item.ContentHTML, item.SummaryText, item.ImageURL, item.DiscountPct, item.Updated, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to upsert history for %s: %w", item.ID, err)
+	}
+	return nil
+}
+
+// AllForStore returns every entry ever recorded for store, including ones
+// that have since rolled off the upstream API.
+func (h *HistoryStore) AllForStore(ctx context.Context, store string) ([]FeedItem, error) {
+	rows, err := h.db.QueryContext(ctx, `
+SELECT id, title, url, content_html, summary_text, image_url, discount_pct, valid_from
+FROM product_history WHERE store = ?`, store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history for %s: %w", store, err)
+	}
+	defer rows.Close()
+
+	var items []FeedItem
+	for rows.Next() {
+		var item FeedItem
+		if err := rows.Scan(&item.ID, &item.Title, &item.URL, &item.ContentHTML, &item.SummaryText, &item.ImageURL, &item.DiscountPct, &item.Updated); err != nil {
+			return nil, fmt.Errorf("failed to scan history row: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (h *HistoryStore) Close() error {
+	return h.db.Close()
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Feed is the store-agnostic, format-agnostic representation of an advent
+// calendar feed. buildFeed produces one from raw API data; the atom/rss/
+// jsonfeed serializers each consume it to produce their wire format, so
+// adding a new output format never requires touching the scraping or
+// feed-assembly code.
+type Feed struct {
+	Title      string
+	Subtitle   string
+	Link       string
+	IconURL    string
+	Updated    time.Time
+	ID         string
+	AuthorName string
+	AuthorURI  string
+	Items      []FeedItem
+}
+
+// FeedItem is one advent-calendar entry, already rendered to the HTML
+// fragment every serializer embeds (Atom content, RSS description,
+// JSON Feed content_html).
+type FeedItem struct {
+	Title          string
+	URL            string
+	ID             string
+	Updated        time.Time
+	SummaryText    string
+	ContentHTML    string
+	ImageURL       string
+	DiscountPct    int
+	Price          float64
+	Currency       string
+	StockRemaining int
+}
+
+// mergeHistory unions fresh's items with persisted ones, preferring the
+// fresh copy of any item still present in the live API response and
+// falling back to the persisted snapshot for items that have since
+// rolled off it. The result is sorted by validFrom descending.
+func mergeHistory(fresh *Feed, persisted []FeedItem) *Feed {
+	seen := make(map[string]bool, len(fresh.Items))
+	for _, item := range fresh.Items {
+		seen[item.ID] = true
+	}
+
+	merged := *fresh
+	merged.Items = make([]FeedItem, len(fresh.Items), len(fresh.Items)+len(persisted))
+	copy(merged.Items, fresh.Items)
+	for _, item := range persisted {
+		if !seen[item.ID] {
+			merged.Items = append(merged.Items, item)
+		}
+	}
+
+	sort.Slice(merged.Items, func(i, j int) bool {
+		return merged.Items[i].Updated.After(merged.Items[j].Updated)
+	})
+
+	return &merged
+}
+
+func buildFeed(calendar *AdventCalendar, store StoreConfig) *Feed {
+	// Sort products by validFrom date descending
+	products := make([]Product, len(calendar.Products))
+	copy(products, calendar.Products)
+	sort.Slice(products, func(i, j int) bool {
+		ti := parseValidFrom(products[i].Offer.SalesInformation.ValidFrom)
+		tj := parseValidFrom(products[j].Offer.SalesInformation.ValidFrom)
+		return ti.After(tj)
+	})
+
+	items := make([]FeedItem, 0, len(products))
+	for _, p := range products {
+		items = append(items, buildFeedItem(p, store))
+	}
+
+	// Fix protocol-relative URL for icon
+	iconURL := calendar.Header.ImageURL
+	if len(iconURL) > 2 && iconURL[:2] == "//" {
+		iconURL = "https:" + iconURL
+	}
+
+	return &Feed{
+		Title:      fmt.Sprintf("%s - %s", calendar.Header.Title, store.Name),
+		Subtitle:   calendar.Header.Description,
+		Link:       store.BaseURL + "/advent-calendar",
+		IconURL:    iconURL,
+		Updated:    time.Now(),
+		ID:         "urn:advent-calendar:" + store.Name,
+		AuthorName: store.Name,
+		AuthorURI:  store.BaseURL,
+		Items:      items,
+	}
+}
+
+func buildFeedItem(p Product, store StoreConfig) FeedItem {
+	productURL := fmt.Sprintf("%s/product/%d", store.BaseURL, p.Product.ProductID)
+	stableID := fmt.Sprintf("urn:advent:%d", p.Product.ProductID)
+
+	// Calculate discount
+	discount := 0
+	if p.Offer.InsteadOfPrice != nil {
+		discount = calculateDiscount(p.Offer.Price.AmountInclusive, p.Offer.InsteadOfPrice.Price.AmountInclusive)
+	}
+
+	// Build title with discount
+	title := fmt.Sprintf("%s: %s", p.Product.BrandName, p.Product.Name)
+	if p.Product.NameProperties != "" {
+		title += " - " + p.Product.NameProperties
+	}
+	if discount > 0 {
+		title = fmt.Sprintf("[%d%% off] %s", discount, title)
+	}
+
+	validFrom := parseValidFrom(p.Offer.SalesInformation.ValidFrom)
+
+	// Build image tag
+	imageURL := ""
+	imageHTML := ""
+	if len(p.Product.Images) > 0 {
+		imageURL = p.Product.Images[0].URL
+		imageHTML = fmt.Sprintf(`<img src="%s" alt="%s" style="max-width:400px;"/><br/><br/>`, imageURL, p.Product.Name)
+	}
+
+	// Stock info
+	remaining := p.Offer.SalesInformation.NumberOfItems - p.Offer.SalesInformation.NumberOfItemsSold
+	stockInfo := fmt.Sprintf("%d/%d remaining", remaining, p.Offer.SalesInformation.NumberOfItems)
+
+	// Price info
+	priceInfo := fmt.Sprintf("%.2f %s", p.Offer.Price.AmountInclusive, p.Offer.Price.Currency)
+	if p.Offer.InsteadOfPrice != nil {
+		priceInfo = fmt.Sprintf("<strong>%.2f %s</strong> <s>%.2f %s</s>",
+			p.Offer.Price.AmountInclusive, p.Offer.Price.Currency,
+			p.Offer.InsteadOfPrice.Price.AmountInclusive, p.Offer.Price.Currency)
+	}
+
+	// Rating info
+	ratingInfo := ""
+	if p.Product.TotalRatings > 0 {
+		ratingInfo = fmt.Sprintf("%.1f/5 (%d reviews)", p.Product.AverageRating, p.Product.TotalRatings)
+	}
+
+	// Build content HTML
+	content := fmt.Sprintf(`%s<p><strong>Brand:</strong> %s</p>
+<p><strong>Type:</strong> %s</p>
+<p><strong>Price:</strong> %s</p>
+<p><strong>Stock:</strong> %s</p>`,
+		imageHTML,
+		p.Product.BrandName,
+		p.Product.ProductTypeName,
+		priceInfo,
+		stockInfo)
+
+	if ratingInfo != "" {
+		content += fmt.Sprintf("\n<p><strong>Rating:</strong> %s</p>", ratingInfo)
+	}
+
+	content += fmt.Sprintf(`<p><a href="%s">View on %s</a></p>`, productURL, store.Name)
+
+	return FeedItem{
+		Title:          title,
+		URL:            productURL,
+		ID:             stableID,
+		Updated:        validFrom,
+		SummaryText:    fmt.Sprintf("%s - %s - %s", p.Product.BrandName, p.Product.ProductTypeName, priceInfo),
+		ContentHTML:    content,
+		ImageURL:       imageURL,
+		DiscountPct:    discount,
+		Price:          p.Offer.Price.AmountInclusive,
+		Currency:       p.Offer.Price.Currency,
+		StockRemaining: remaining,
+	}
+}
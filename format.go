@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// feedFormat identifies an output serialization for a Feed.
+type feedFormat string
+
+const (
+	formatAtom     feedFormat = "atom"
+	formatRSS      feedFormat = "rss"
+	formatJSONFeed feedFormat = "json"
+)
+
+var formatContentType = map[feedFormat]string{
+	formatAtom:     "application/atom+xml; charset=utf-8",
+	formatRSS:      "application/rss+xml; charset=utf-8",
+	formatJSONFeed: "application/feed+json; charset=utf-8",
+}
+
+var formatSerializer = map[feedFormat]func(io.Writer, *Feed) error{
+	formatAtom:     serializeAtom,
+	formatRSS:      serializeRSS,
+	formatJSONFeed: serializeJSONFeed,
+}
+
+// resolveFormat picks the output format for a request: an explicit
+// ?format= query parameter wins, otherwise it falls back to content
+// negotiation on Accept, defaulting to Atom for compatibility with
+// existing subscribers.
+func resolveFormat(r *http.Request) feedFormat {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "rss":
+		return formatRSS
+	case "json", "jsonfeed":
+		return formatJSONFeed
+	case "atom":
+		return formatAtom
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/feed+json"), strings.Contains(accept, "application/json"):
+		return formatJSONFeed
+	case strings.Contains(accept, "application/rss+xml"):
+		return formatRSS
+	}
+
+	return formatAtom
+}
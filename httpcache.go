@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// strongETag returns a strong ETag for body, per RFC 7232 ยง2.3.
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// remainingTTL is how much longer a feed fetched at fetchedAt stays valid
+// given the registry's cache duration, floored at zero.
+func remainingTTL(fetchedAt time.Time, duration time.Duration) time.Duration {
+	remaining := duration - time.Since(fetchedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// notModified reports whether the request's conditional headers indicate
+// the client's cached copy, identified by etag/lastModified, is still
+// current.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		return match == etag
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !lastModified.After(t)
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSON Feed 1.1 structures (https://www.jsonfeed.org/version/1.1/)
+type JSONFeedDoc struct {
+	Version     string          `json:"version"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	HomePageURL string          `json:"home_page_url,omitempty"`
+	Icon        string          `json:"icon,omitempty"`
+	Author      *JSONFeedAuthor `json:"author,omitempty"`
+	Items       []JSONFeedItem  `json:"items"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type JSONFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url,omitempty"`
+	Title         string `json:"title"`
+	ContentHTML   string `json:"content_html"`
+	Summary       string `json:"summary,omitempty"`
+	Image         string `json:"image,omitempty"`
+	DatePublished string `json:"date_published"`
+	Discount      int    `json:"_discount,omitempty"`
+}
+
+// toJSONFeed converts the neutral Feed model into a JSON Feed 1.1 document.
+func toJSONFeed(f *Feed) *JSONFeedDoc {
+	items := make([]JSONFeedItem, 0, len(f.Items))
+	for _, item := range f.Items {
+		items = append(items, JSONFeedItem{
+			ID:            item.ID,
+			URL:           item.URL,
+			Title:         item.Title,
+			ContentHTML:   item.ContentHTML,
+			Summary:       item.SummaryText,
+			Image:         item.ImageURL,
+			DatePublished: item.Updated.Format(time.RFC3339),
+			Discount:      item.DiscountPct,
+		})
+	}
+
+	var author *JSONFeedAuthor
+	if f.AuthorName != "" {
+		author = &JSONFeedAuthor{Name: f.AuthorName, URL: f.AuthorURI}
+	}
+
+	return &JSONFeedDoc{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		Description: f.Subtitle,
+		HomePageURL: f.Link,
+		Icon:        f.IconURL,
+		Author:      author,
+		Items:       items,
+	}
+}
+
+// serializeJSONFeed writes f to w as a JSON Feed 1.1 document.
+func serializeJSONFeed(w io.Writer, f *Feed) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(toJSONFeed(f))
+}
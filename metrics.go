@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	fetchTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "advent_feed_fetch_total",
+		Help: "Number of upstream advent-calendar fetches, per store.",
+	}, []string{"store"})
+
+	fetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "advent_feed_fetch_duration_seconds",
+		Help: "Latency of upstream advent-calendar fetches, per store.",
+	}, []string{"store"})
+
+	fetchErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "advent_feed_fetch_errors_total",
+		Help: "Number of failed upstream fetches, per store and error kind.",
+	}, []string{"store", "kind"})
+
+	cacheResultTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "advent_feed_cache_result_total",
+		Help: "Cache hits vs misses serving a store's feed.",
+	}, []string{"store", "result"})
+
+	feedBytesServedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "advent_feed_bytes_served_total",
+		Help: "Bytes of serialized feed served, per output format.",
+	}, []string{"format"})
+
+	activeEntries = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "advent_feed_active_entries",
+		Help: "Number of entries currently in a store's feed.",
+	}, []string{"store"})
+)
+
+// fetchErrorKind extracts the FetchErrorKind from err, falling back to
+// "unknown" for errors that didn't originate from a Scraper.
+func fetchErrorKind(err error) string {
+	var fe *FetchError
+	if errors.As(err, &fe) {
+		return string(fe.Kind)
+	}
+	return "unknown"
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// EventKind identifies what changed about a product between two fetches.
+type EventKind string
+
+const (
+	EventNewDay    EventKind = "new_day"
+	EventPriceDrop EventKind = "price_drop"
+	EventLowStock  EventKind = "low_stock"
+)
+
+// Event describes a single change worth telling someone about.
+type Event struct {
+	Kind           EventKind `json:"kind"`
+	Store          string    `json:"store"`
+	ProductID      string    `json:"product_id"`
+	Title          string    `json:"title"`
+	URL            string    `json:"url"`
+	Currency       string    `json:"currency,omitempty"`
+	OldPrice       float64   `json:"old_price,omitempty"`
+	NewPrice       float64   `json:"new_price,omitempty"`
+	StockRemaining int       `json:"stock_remaining,omitempty"`
+}
+
+// Notifier dispatches an Event to some external system.
+type Notifier interface {
+	Notify(ctx context.Context, ev Event) error
+}
+
+// diffItems compares a store's previous and current snapshots and
+// produces the notification-worthy events: a new day revealed, a price
+// drop versus what was last seen, or stock crossing the low-water mark.
+// oldItems being nil means this is the first snapshot ever taken for the
+// store, so there is nothing to diff against yet; no events are produced
+// to avoid treating every already-revealed day as "new" on startup.
+func diffItems(store string, oldItems, newItems []FeedItem, lowStock int) []Event {
+	if oldItems == nil {
+		return nil
+	}
+
+	oldByID := make(map[string]FeedItem, len(oldItems))
+	for _, item := range oldItems {
+		oldByID[item.ID] = item
+	}
+
+	var events []Event
+	for _, item := range newItems {
+		old, existed := oldByID[item.ID]
+		if !existed {
+			events = append(events, Event{
+				Kind:      EventNewDay,
+				Store:     store,
+				ProductID: item.ID,
+				Title:     item.Title,
+				URL:       item.URL,
+				Currency:  item.Currency,
+				NewPrice:  item.Price,
+			})
+			continue
+		}
+
+		if item.Price < old.Price {
+			events = append(events, Event{
+				Kind:      EventPriceDrop,
+				Store:     store,
+				ProductID: item.ID,
+				Title:     item.Title,
+				URL:       item.URL,
+				Currency:  item.Currency,
+				OldPrice:  old.Price,
+				NewPrice:  item.Price,
+			})
+		}
+
+		if lowStock > 0 && item.StockRemaining <= lowStock && old.StockRemaining > lowStock {
+			events = append(events, Event{
+				Kind:           EventLowStock,
+				Store:          store,
+				ProductID:      item.ID,
+				Title:          item.Title,
+				URL:            item.URL,
+				StockRemaining: item.StockRemaining,
+			})
+		}
+	}
+	return events
+}
+
+// withRetry calls fn until it succeeds, ctx is done, or attempts is
+// exhausted, backing off exponentially between tries.
+func withRetry(ctx context.Context, attempts int, fn func() error) error {
+	backoff := 500 * time.Millisecond
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if i == attempts-1 {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+}
+
+func eventMessage(ev Event) (title, body string) {
+	switch ev.Kind {
+	case EventNewDay:
+		return fmt.Sprintf("[%s] New day revealed", ev.Store),
+			fmt.Sprintf("%s - %.2f %s\n%s", ev.Title, ev.NewPrice, ev.Currency, ev.URL)
+	case EventPriceDrop:
+		return fmt.Sprintf("[%s] Price drop", ev.Store),
+			fmt.Sprintf("%s - %.2f %s (was %.2f %s)\n%s", ev.Title, ev.NewPrice, ev.Currency, ev.OldPrice, ev.Currency, ev.URL)
+	case EventLowStock:
+		return fmt.Sprintf("[%s] Low stock", ev.Store),
+			fmt.Sprintf("%s - %d remaining\n%s", ev.Title, ev.StockRemaining, ev.URL)
+	default:
+		return fmt.Sprintf("[%s] %s", ev.Store, ev.Kind), ev.Title
+	}
+}
+
+// WebhookNotifier POSTs the raw Event as JSON to a generic webhook URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return withRetry(ctx, 4, func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", n.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// AppriseNotifier posts a title/body notification to a caronc/apprise API
+// server's stateless /notify endpoint, letting users fan out to whatever
+// services they've configured Apprise with. The stateless endpoint
+// requires the target Apprise URLs in every request body, since there is
+// no persistent "key" to look them up by.
+type AppriseNotifier struct {
+	baseURL string
+	urls    string
+	client  *http.Client
+}
+
+func NewAppriseNotifier(baseURL, urls string) *AppriseNotifier {
+	return &AppriseNotifier{baseURL: strings.TrimRight(baseURL, "/"), urls: urls, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *AppriseNotifier) Notify(ctx context.Context, ev Event) error {
+	title, msgBody := eventMessage(ev)
+	payload, err := json.Marshal(map[string]string{"urls": n.urls, "title": title, "body": msgBody})
+	if err != nil {
+		return fmt.Errorf("failed to marshal apprise payload: %w", err)
+	}
+
+	return withRetry(ctx, 4, func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", n.baseURL+"/notify", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("apprise returned status %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// MultiNotifier fans an event out to every configured Notifier.
+type MultiNotifier []Notifier
+
+func (m MultiNotifier) Notify(ctx context.Context, ev Event) error {
+	var firstErr error
+	for _, n := range m {
+		if err := n.Notify(ctx, ev); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
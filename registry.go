@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// storeEntry pairs a backend scraper with its own cache, so each store is
+// refreshed and served independently.
+type storeEntry struct {
+	scraper Scraper
+	cache   *Cache
+
+	snapshotMu sync.Mutex
+	snapshot   []FeedItem // fresh items as of the last successful upstream fetch, for notification diffing
+}
+
+// FeedRegistry holds one Cache per configured store and knows how to
+// refresh all of them concurrently and merge their entries into a single
+// aggregated feed. It mirrors the plugin-registry approach of fetching
+// several backends through one dispatcher rather than binding the server
+// to a single store.
+type FeedRegistry struct {
+	entries        map[string]*storeEntry
+	names          []string // stable iteration order for listing/aggregation
+	cacheDuration  time.Duration
+	history        *HistoryStore
+	notifier       Notifier
+	lowStock       int
+	aggregateCache *Cache
+
+	ready atomic.Bool // set once the first store has been fetched successfully
+}
+
+// NewFeedRegistry builds a registry from the given scrapers, keyed by
+// store name. cacheDuration is shared by every per-store cache. history
+// and notifier may both be nil to disable persistence/notifications;
+// lowStock is the stock-remaining threshold that triggers a low-stock
+// event and is ignored when notifier is nil.
+func NewFeedRegistry(scrapers map[string]Scraper, cacheDuration time.Duration, history *HistoryStore, notifier Notifier, lowStock int) *FeedRegistry {
+	r := &FeedRegistry{
+		entries:        make(map[string]*storeEntry, len(scrapers)),
+		cacheDuration:  cacheDuration,
+		history:        history,
+		notifier:       notifier,
+		lowStock:       lowStock,
+		aggregateCache: &Cache{duration: cacheDuration},
+	}
+	for name, s := range scrapers {
+		r.entries[name] = &storeEntry{
+			scraper: s,
+			cache:   &Cache{duration: cacheDuration},
+		}
+		r.names = append(r.names, name)
+	}
+	sort.Strings(r.names)
+	return r
+}
+
+// Names returns the configured store names in a stable order.
+func (r *FeedRegistry) Names() []string {
+	return r.names
+}
+
+// Has reports whether name is a known store.
+func (r *FeedRegistry) Has(name string) bool {
+	_, ok := r.entries[name]
+	return ok
+}
+
+// StoreName returns the display name for a configured store.
+func (r *FeedRegistry) StoreName(name string) string {
+	if e, ok := r.entries[name]; ok {
+		return e.scraper.Info().Name
+	}
+	return name
+}
+
+// CacheDuration returns the TTL shared by every per-store cache.
+func (r *FeedRegistry) CacheDuration() time.Duration {
+	return r.cacheDuration
+}
+
+// Ready reports whether at least one store has been fetched successfully
+// since startup, for use by a readiness probe.
+func (r *FeedRegistry) Ready() bool {
+	return r.ready.Load()
+}
+
+// Get returns the feed for a single store, serving from cache when fresh,
+// along with the time that feed was fetched.
+func (r *FeedRegistry) Get(ctx context.Context, name string) (*Feed, time.Time, error) {
+	e, ok := r.entries[name]
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("unknown store: %s", name)
+	}
+	feed, err := r.refresh(ctx, e)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	fetchedAt, _ := e.cache.Meta()
+	return feed, fetchedAt, nil
+}
+
+func (r *FeedRegistry) refresh(ctx context.Context, e *storeEntry) (*Feed, error) {
+	store := e.scraper.Info()
+
+	if cached := e.cache.Get(); cached != nil {
+		cacheResultTotal.WithLabelValues(store.Name, "hit").Inc()
+		slog.Debug("serving feed from cache", "store", store.Name)
+		return cached, nil
+	}
+	cacheResultTotal.WithLabelValues(store.Name, "miss").Inc()
+
+	slog.Info("fetching fresh data", "store", store.Name)
+	fetchTotal.WithLabelValues(store.Name).Inc()
+	start := time.Now()
+	calendar, err := e.scraper.Fetch(ctx)
+	fetchDuration.WithLabelValues(store.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		fetchErrorsTotal.WithLabelValues(store.Name, fetchErrorKind(err)).Inc()
+		slog.Error("fetch failed", "store", store.Name, "kind", fetchErrorKind(err), "err", err)
+		return nil, err
+	}
+	r.ready.Store(true)
+
+	fresh := buildFeed(calendar, store)
+	if r.notifier != nil {
+		r.notifyChanges(store.Name, e, fresh.Items)
+	}
+
+	feed := fresh
+	if r.history != nil {
+		feed = r.withHistory(ctx, store.Name, feed)
+	}
+	e.cache.Set(feed)
+	activeEntries.WithLabelValues(store.Name).Set(float64(len(feed.Items)))
+	return feed, nil
+}
+
+// notifyChanges diffs newItems against the store's last known snapshot
+// and dispatches any resulting events in the background, so a slow or
+// unreachable notification endpoint never delays serving the feed.
+func (r *FeedRegistry) notifyChanges(storeName string, e *storeEntry, newItems []FeedItem) {
+	e.snapshotMu.Lock()
+	oldItems := e.snapshot
+	e.snapshot = newItems
+	e.snapshotMu.Unlock()
+
+	events := diffItems(storeName, oldItems, newItems, r.lowStock)
+	for _, ev := range events {
+		ev := ev
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := r.notifier.Notify(ctx, ev); err != nil {
+				slog.Warn("failed to deliver notification", "kind", ev.Kind, "product_id", ev.ProductID, "err", err)
+			}
+		}()
+	}
+}
+
+// withHistory persists fresh's items to the history store and unions them
+// with every entry ever recorded for the store, so days that have rolled
+// off the upstream API stay in the feed.
+func (r *FeedRegistry) withHistory(ctx context.Context, storeName string, fresh *Feed) *Feed {
+	for _, item := range fresh.Items {
+		if err := r.history.Upsert(ctx, storeName, item); err != nil {
+			slog.Warn("failed to persist history", "store", storeName, "err", err)
+		}
+	}
+
+	persisted, err := r.history.AllForStore(ctx, storeName)
+	if err != nil {
+		slog.Warn("failed to load history", "store", storeName, "err", err)
+		return fresh
+	}
+
+	return mergeHistory(fresh, persisted)
+}
+
+// GetAll refreshes every configured store in parallel via an errgroup and
+// returns the per-store feeds keyed by store name. Each store refreshes
+// against the caller's ctx directly rather than an errgroup-derived one,
+// so one store failing never cancels its siblings' in-flight requests;
+// only when every store fails is an error propagated.
+func (r *FeedRegistry) GetAll(ctx context.Context) (map[string]*Feed, error) {
+	var eg errgroup.Group
+
+	var (
+		mu    sync.Mutex
+		feeds = make(map[string]*Feed, len(r.entries))
+	)
+
+	for name, e := range r.entries {
+		name, e := name, e
+		eg.Go(func() error {
+			feed, err := r.refresh(ctx, e)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			mu.Lock()
+			feeds[name] = feed
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil && len(feeds) == 0 {
+		return nil, err
+	}
+	return feeds, nil
+}
+
+// Aggregate merges every store's entries into a single feed sorted by
+// validFrom descending, so a subscriber can follow one URL for every
+// configured store at once. The merged feed is itself cached for
+// cacheDuration so its serialized bytes (and therefore its ETag) stay
+// stable between refreshes, letting conditional GETs 304 on this endpoint
+// too. The returned time is the newest fetchedAt among the contributing
+// stores.
+func (r *FeedRegistry) Aggregate(ctx context.Context) (*Feed, time.Time, error) {
+	if cached := r.aggregateCache.Get(); cached != nil {
+		return cached, cached.Updated, nil
+	}
+
+	feeds, err := r.GetAll(ctx)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	merged := &Feed{
+		Title: "Advent Calendar Feed - All Stores",
+		Link:  "/feed",
+		ID:    "urn:advent-calendar:aggregate",
+	}
+
+	var newest time.Time
+	for _, name := range r.names {
+		feed, ok := feeds[name]
+		if !ok {
+			continue
+		}
+		merged.Items = append(merged.Items, feed.Items...)
+
+		if fetchedAt, _ := r.entries[name].cache.Meta(); fetchedAt.After(newest) {
+			newest = fetchedAt
+		}
+	}
+	merged.Updated = newest
+
+	sort.Slice(merged.Items, func(i, j int) bool {
+		return merged.Items[i].Updated.After(merged.Items[j].Updated)
+	})
+
+	r.aggregateCache.Set(merged)
+	return merged, merged.Updated, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// RSS 2.0 structures
+type RSSFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Image         *RSSImage `xml:"image,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []RSSItem `xml:"item"`
+}
+
+type RSSImage struct {
+	URL   string `xml:"url"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
+type RSSItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        RSSGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type RSSGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// toRSSFeed converts the neutral Feed model into RSS 2.0 structures.
+func toRSSFeed(f *Feed) *RSSFeed {
+	items := make([]RSSItem, 0, len(f.Items))
+	for _, item := range f.Items {
+		items = append(items, RSSItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        RSSGUID{IsPermaLink: "false", Value: item.ID},
+			PubDate:     item.Updated.Format(time.RFC1123Z),
+			Description: item.ContentHTML,
+		})
+	}
+
+	var image *RSSImage
+	if f.IconURL != "" {
+		image = &RSSImage{URL: f.IconURL, Title: f.Title, Link: f.Link}
+	}
+
+	return &RSSFeed{
+		Version: "2.0",
+		Channel: RSSChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Subtitle,
+			Image:         image,
+			LastBuildDate: f.Updated.Format(time.RFC1123Z),
+			Items:         items,
+		},
+	}
+}
+
+// serializeRSS writes f to w as an RSS 2.0 document.
+func serializeRSS(w io.Writer, f *Feed) error {
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(toRSSFeed(f))
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FetchErrorKind classifies why a Scraper.Fetch call failed, so callers
+// can break down failure metrics without string-matching error messages.
+type FetchErrorKind string
+
+const (
+	FetchErrorRequest FetchErrorKind = "request"
+	FetchErrorNetwork FetchErrorKind = "network"
+	FetchErrorStatus  FetchErrorKind = "status"
+	FetchErrorDecode  FetchErrorKind = "decode"
+	FetchErrorEmpty   FetchErrorKind = "empty"
+)
+
+// FetchError wraps a Scraper.Fetch failure with its FetchErrorKind.
+type FetchError struct {
+	Kind FetchErrorKind
+	Err  error
+}
+
+func (e *FetchError) Error() string { return fmt.Sprintf("%s: %v", e.Kind, e.Err) }
+func (e *FetchError) Unwrap() error { return e.Err }
+
+// Scraper fetches an advent calendar from a single retailer backend and
+// describes itself via Info. New retailers (a generic JSON API, a
+// different GraphQL schema, whatever else shows up) implement this
+// interface and register a factory instead of touching the feed-building
+// code in atom.go or registry.go.
+type Scraper interface {
+	Fetch(ctx context.Context) (*AdventCalendar, error)
+	Info() StoreConfig
+}
+
+// ScraperFactory builds a Scraper for a given user agent. Registered
+// factories are keyed by store name, mirroring a plugin-style dispatcher
+// where each backend owns its own construction.
+type ScraperFactory func(userAgent string) Scraper
+
+var scraperFactories = map[string]ScraperFactory{}
+
+// RegisterScraper makes a backend available under name. It is expected to
+// be called from package init so all built-in backends are available
+// before main runs.
+func RegisterScraper(name string, factory ScraperFactory) {
+	scraperFactories[name] = factory
+}
+
+func init() {
+	for name, store := range stores {
+		store := store
+		RegisterScraper(name, func(userAgent string) Scraper {
+			return NewGraphQLScraper(store, userAgent)
+		})
+	}
+}
+
+// GraphQLScraper is the Scraper implementation for the Galaxus/Digitec
+// advent calendar GraphQL endpoint.
+type GraphQLScraper struct {
+	store     StoreConfig
+	userAgent string
+	client    *http.Client
+
+	mu           sync.Mutex
+	lastCalendar *AdventCalendar
+	lastDate     time.Time
+}
+
+func NewGraphQLScraper(store StoreConfig, userAgent string) *GraphQLScraper {
+	return &GraphQLScraper{
+		store:     store,
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *GraphQLScraper) Info() StoreConfig {
+	return s.store
+}
+
+// Fetch asks the upstream GraphQL endpoint for the advent calendar. Once a
+// response has been seen, subsequent calls send If-Modified-Since based on
+// its currentDate so polling a calendar that changes at most once a day
+// doesn't re-transfer the same payload.
+func (s *GraphQLScraper) Fetch(ctx context.Context) (*AdventCalendar, error) {
+	reqBody := []GraphQLRequest{{
+		OperationName: "GET_ADVENTCALENDAR",
+		Variables:     map[string]any{},
+		Query:         graphQLQuery,
+	}}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, &FetchError{Kind: FetchErrorRequest, Err: fmt.Errorf("failed to marshal request: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.store.APIURL, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, &FetchError{Kind: FetchErrorRequest, Err: fmt.Errorf("failed to create request: %w", err)}
+	}
+
+	// Set headers matching the browser request
+	req.Header.Set("Accept", "*/*")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", s.store.BaseURL)
+	req.Header.Set("Pragma", "no-cache")
+	req.Header.Set("User-Agent", s.userAgent)
+
+	// Store-specific headers
+	req.Header.Set("x-dg-graphql-client-name", "isomorph")
+	req.Header.Set("x-dg-language", "de-CH")
+	req.Header.Set("x-dg-portal", s.store.PortalID)
+	req.Header.Set("x-dg-routename", "/advent-calendar")
+	req.Header.Set("x-dg-routeowner", "stellapolaris")
+	req.Header.Set("x-dg-team", "stellapolaris")
+
+	s.mu.Lock()
+	lastCalendar, lastDate := s.lastCalendar, s.lastDate
+	s.mu.Unlock()
+	if !lastDate.IsZero() {
+		req.Header.Set("If-Modified-Since", lastDate.Format(http.TimeFormat))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, &FetchError{Kind: FetchErrorNetwork, Err: fmt.Errorf("failed to fetch data: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && lastCalendar != nil {
+		return lastCalendar, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &FetchError{Kind: FetchErrorStatus, Err: fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))}
+	}
+
+	var apiResp []APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, &FetchError{Kind: FetchErrorDecode, Err: fmt.Errorf("failed to decode response: %w", err)}
+	}
+
+	if len(apiResp) == 0 {
+		return nil, &FetchError{Kind: FetchErrorEmpty, Err: fmt.Errorf("empty response from API")}
+	}
+
+	calendar := &apiResp[0].Data.AdventCalendar
+
+	s.mu.Lock()
+	s.lastCalendar = calendar
+	if t, err := time.Parse(time.RFC3339, calendar.CurrentDate); err == nil {
+		s.lastDate = t
+	}
+	s.mu.Unlock()
+
+	return calendar, nil
+}
@@ -0,0 +1,263 @@
+package main
+
+// Store configuration
+type StoreConfig struct {
+	Name     string
+	APIURL   string
+	BaseURL  string
+	PortalID string
+}
+
+var stores = map[string]StoreConfig{
+	"galaxus": {
+		Name:     "Galaxus",
+		APIURL:   "https://www.galaxus.ch/api/graphql/get-adventcalendar",
+		BaseURL:  "https://www.galaxus.ch",
+		PortalID: "22",
+	},
+	"digitec": {
+		Name:     "Digitec",
+		APIURL:   "https://www.digitec.ch/api/graphql/get-adventcalendar",
+		BaseURL:  "https://www.digitec.ch",
+		PortalID: "25",
+	},
+}
+
+// GraphQL request/response structures
+type GraphQLRequest struct {
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Query         string         `json:"query"`
+}
+
+type APIResponse struct {
+	Data struct {
+		AdventCalendar AdventCalendar `json:"adventCalendar"`
+	} `json:"data"`
+}
+
+type AdventCalendar struct {
+	CurrentDate string    `json:"currentDate"`
+	Header      Header    `json:"header"`
+	Products    []Product `json:"products"`
+}
+
+type Header struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"imageUrl"`
+}
+
+type Product struct {
+	Product struct {
+		ID              string  `json:"id"`
+		ProductID       int     `json:"productId"`
+		Name            string  `json:"name"`
+		NameProperties  string  `json:"nameProperties"`
+		ProductTypeName string  `json:"productTypeName"`
+		BrandName       string  `json:"brandName"`
+		AverageRating   float64 `json:"averageRating"`
+		TotalRatings    int     `json:"totalRatings"`
+		Images          []struct {
+			URL string `json:"url"`
+		} `json:"images"`
+	} `json:"product"`
+	Offer struct {
+		Price struct {
+			AmountInclusive float64 `json:"amountInclusive"`
+			Currency        string  `json:"currency"`
+		} `json:"price"`
+		SalesInformation struct {
+			NumberOfItems     int    `json:"numberOfItems"`
+			NumberOfItemsSold int    `json:"numberOfItemsSold"`
+			ValidFrom         string `json:"validFrom"`
+		} `json:"salesInformation"`
+		InsteadOfPrice *struct {
+			Price struct {
+				AmountInclusive float64 `json:"amountInclusive"`
+			} `json:"price"`
+		} `json:"insteadOfPrice"`
+	} `json:"offer"`
+}
+
+const graphQLQuery = `query GET_ADVENTCALENDAR($date: String) {
+  adventCalendar(date: $date) {
+    currentDate
+    header {
+      title
+      description
+      imageUrl
+      __typename
+    }
+    products {
+      ...ProductWithOffer
+      __typename
+    }
+    __typename
+  }
+}
+
+fragment ProductWithOffer on ProductWithOffer {
+  mandatorSpecificData {
+    ...ProductMandatorSpecific
+    __typename
+  }
+  product {
+    ...ProductMandatorIndependent
+    __typename
+  }
+  offer {
+    ...ProductOffer
+    __typename
+  }
+  isDefaultOffer
+  __typename
+}
+
+fragment ProductMandatorSpecific on MandatorSpecificData {
+  isBestseller
+  isDeleted
+  sectorIds
+  hasVariants
+  showrooms {
+    siteId
+    name
+    __typename
+  }
+  __typename
+}
+
+fragment ProductMandatorIndependent on ProductV2 {
+  id
+  productId
+  name
+  nameProperties
+  productTypeId
+  productTypeName
+  brandId
+  brandName
+  averageRating
+  totalRatings
+  totalQuestions
+  images {
+    url
+    height
+    width
+    __typename
+  }
+  energyEfficiency {
+    energyEfficiencyColorType
+    energyEfficiencyLabelText
+    energyEfficiencyLabelSigns
+    energyEfficiencyImage {
+      url
+      height
+      width
+      __typename
+    }
+    isNewEnergyEfficiencyLabel
+    __typename
+  }
+  seo {
+    seoProductTypeName
+    seoNameProperties
+    productGroups {
+      productGroup1
+      productGroup2
+      productGroup3
+      productGroup4
+      __typename
+    }
+    gtin
+    __typename
+  }
+  basePrice {
+    priceFactor
+    value
+    __typename
+  }
+  productDataSheet {
+    name
+    languages
+    url
+    size
+    __typename
+  }
+  __typename
+}
+
+fragment ProductOffer on OfferV2 {
+  id
+  productId
+  offerId
+  shopOfferId
+  price {
+    amountInclusive
+    amountExclusive
+    currency
+    __typename
+  }
+  deliveryOptions {
+    mail {
+      classification
+      futureReleaseDate
+      launchesAt
+      __typename
+    }
+    pickup {
+      siteId
+      classification
+      futureReleaseDate
+      launchesAt
+      __typename
+    }
+    detailsProvider {
+      productId
+      offerId
+      refurbishedId
+      resaleId
+      __typename
+    }
+    __typename
+  }
+  label
+  labelType
+  type
+  volumeDiscountPrices {
+    minAmount
+    price {
+      amountInclusive
+      amountExclusive
+      currency
+      __typename
+    }
+    isDefault
+    __typename
+  }
+  salesInformation {
+    numberOfItems
+    numberOfItemsSold
+    isEndingSoon
+    validFrom
+    __typename
+  }
+  incentiveText
+  isIncentiveCashback
+  isNew
+  isSalesPromotion
+  hideInProductDiscovery
+  canAddToBasket
+  hidePrice
+  insteadOfPrice {
+    type
+    price {
+      amountInclusive
+      amountExclusive
+      currency
+      __typename
+    }
+    __typename
+  }
+  minOrderQuantity
+  __typename
+}`